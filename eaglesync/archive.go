@@ -0,0 +1,150 @@
+package eaglesync
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ArchiveFormat selects the container format used when Export streams into
+// a single archive instead of writing files under outputDir.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar   ArchiveFormat = "tar"
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+	ArchiveFormatZip   ArchiveFormat = "zip"
+)
+
+// archiveEntry is one exported file queued for the archive writer goroutine.
+type archiveEntry struct {
+	name  string
+	mtime time.Time
+	size  int64
+	body  io.ReadCloser
+}
+
+// archiveWriter serializes archiveEntry values produced concurrently by the
+// worker pool onto a single tar/zip stream, so only one goroutine ever
+// touches the underlying io.Writer and memory stays bounded regardless of
+// library size.
+type archiveWriter struct {
+	entries chan *archiveEntry
+	done    chan error
+}
+
+func newArchiveWriter(format ArchiveFormat, w io.Writer) (*archiveWriter, error) {
+	aw := &archiveWriter{
+		entries: make(chan *archiveEntry),
+		done:    make(chan error, 1),
+	}
+	go aw.run(format, w)
+	return aw, nil
+}
+
+func (a *archiveWriter) run(format ArchiveFormat, w io.Writer) {
+	a.done <- func() error {
+		switch format {
+		case ArchiveFormatTar:
+			return writeTarEntries(a.entries, w)
+		case ArchiveFormatTarGz:
+			gw := gzip.NewWriter(w)
+			defer func() { _ = gw.Close() }()
+			return writeTarEntries(a.entries, gw)
+		case ArchiveFormatZip:
+			return writeZipEntries(a.entries, w)
+		default:
+			// drain so producers never block on an unknown format
+			for range a.entries {
+			}
+			return errors.Newf("unsupported archive format '%v'", format)
+		}
+	}()
+}
+
+// writeTarEntries always ranges entries to completion, even once it has
+// hit an error: a writer that stops early would leave producers blocked
+// forever on the unbuffered aw.Write send once every other in-flight
+// worker does the same.
+func writeTarEntries(entries <-chan *archiveEntry, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	defer func() { _ = tw.Close() }()
+
+	var result error
+	for entry := range entries {
+		if result != nil {
+			_ = entry.body.Close()
+			continue
+		}
+
+		err := tw.WriteHeader(&tar.Header{
+			Name:    entry.name,
+			Size:    entry.size,
+			Mode:    0644,
+			ModTime: entry.mtime,
+		})
+		if err != nil {
+			_ = entry.body.Close()
+			result = errors.Wrapf(err, "write tar header failed, path: %v", entry.name)
+			continue
+		}
+
+		_, err = io.Copy(tw, entry.body)
+		_ = entry.body.Close()
+		if err != nil {
+			result = errors.Wrapf(err, "write tar entry failed, path: %v", entry.name)
+		}
+	}
+	return result
+}
+
+// writeZipEntries mirrors writeTarEntries: it keeps draining entries after
+// the first error so producers never block on a writer that gave up early.
+func writeZipEntries(entries <-chan *archiveEntry, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	defer func() { _ = zw.Close() }()
+
+	var result error
+	for entry := range entries {
+		if result != nil {
+			_ = entry.body.Close()
+			continue
+		}
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     entry.name,
+			Modified: entry.mtime,
+			Method:   zip.Deflate,
+		})
+		if err != nil {
+			_ = entry.body.Close()
+			result = errors.Wrapf(err, "create zip entry failed, path: %v", entry.name)
+			continue
+		}
+
+		_, err = io.Copy(fw, entry.body)
+		_ = entry.body.Close()
+		if err != nil {
+			result = errors.Wrapf(err, "write zip entry failed, path: %v", entry.name)
+		}
+	}
+	return result
+}
+
+// Write hands an entry to the archive goroutine, blocking for backpressure
+// when the writer is busy with a previous entry.
+func (a *archiveWriter) Write(entry *archiveEntry) error {
+	a.entries <- entry
+	return nil
+}
+
+// Close signals that no more entries are coming and waits for the archive
+// goroutine to flush and close the underlying writer.
+func (a *archiveWriter) Close() error {
+	close(a.entries)
+	return <-a.done
+}
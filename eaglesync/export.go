@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/djherbis/times"
@@ -37,9 +38,48 @@ type ExportOption struct {
 
 	// GroupBySmartFolder export group by smart folder
 	GroupBySmartFolder bool
+
+	// ArchiveFormat streams the export into a single tar/tar.gz/zip archive
+	// instead of writing files under outputDir. Empty disables archiving.
+	ArchiveFormat ArchiveFormat
+
+	// ArchiveWriter receives the archive bytes when ArchiveFormat is set. If
+	// nil, outputDir is treated as the archive's destination file path.
+	ArchiveWriter io.Writer
+
+	// HTTPSink streams each exported file to an HTTP endpoint as a
+	// multipart/form-data POST instead of writing it under outputDir.
+	HTTPSink *HTTPSinkConfig
+
+	// DryRun walks the same code paths as a real export and records what it
+	// would have done in Report, without touching outputDir.
+	DryRun bool
+
+	// Report, when set, collects per-file failures (and, under DryRun,
+	// planned actions) instead of Export aborting on the first error.
+	Report *ExportReport
+
+	// ResumeFile, if set, is the path, on the destination filesystem and used
+	// as given, of an append-only log of finished Eagle file ids. A re-run
+	// skips ids it already contains, so a crashed
+	// export can be resumed without re-copying everything.
+	ResumeFile string
 }
 
 func (e *Library) Export(outputDir string, option ExportOption) error {
+	if option.ArchiveFormat != "" {
+		return e.exportArchive(outputDir, option)
+	}
+
+	if option.HTTPSink != nil {
+		return e.exportHTTP(option)
+	}
+
+	// DryRun leaves outputDir untouched, so Force's cleanup is skipped too.
+	if option.DryRun {
+		option.Force = false
+	}
+
 	if option.Force {
 		err := e.fs.RemoveAll(outputDir)
 		if err != nil {
@@ -47,23 +87,330 @@ func (e *Library) Export(outputDir string, option ExportOption) error {
 		}
 	}
 
+	mtimeMap, filter, count, err := e.loadExportSources()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := loadManifest(e.fs, outputDir)
+	if err != nil {
+		return err
+	}
+
+	var resume *resumeLog
+	if option.ResumeFile != "" {
+		resume, err = openResumeLog(e.fs, option.ResumeFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resume.Close() }()
+	}
+
+	bar := option.Bar
+	if bar != nil {
+		bar.ChangeMax64(count)
+		defer func() { _ = bar.Finish() }()
+	}
+
+	p := pool.New().WithErrors().WithMaxGoroutines(runtime.NumCPU())
+	for fileInfoName, mtime := range mtimeMap {
+		fileInfoName := fileInfoName
+		mtime := mtime
+
+		if fileInfoName == "all" {
+			continue
+		}
+
+		p.Go(func() error {
+			if resumeAlreadyExported(resume, manifest, fileInfoName) {
+				return nil
+			}
+
+			entry, ok, err := e.resolveExportEntry(fileInfoName, filter, option.GroupBySmartFolder)
+			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: fileInfoName, Cause: err})
+					return nil
+				}
+				return err
+			}
+			if !ok {
+				return nil
+			}
+
+			dst := filepath.Join(outputDir, entry.relDst)
+
+			if option.DryRun {
+				action, err := e.planAction(fileInfoName, entry.src, dst, mtime, entry.uncategorized, option.Overwrite, manifest)
+				if err != nil {
+					if option.Report != nil {
+						option.Report.addFailure(ExportFailure{Path: entry.relDst, Category: entry.category, Cause: err})
+						return nil
+					}
+					return err
+				}
+				if option.Report != nil {
+					option.Report.addPlanned(PlannedAction{Path: entry.relDst, Category: entry.category, Action: action})
+				}
+				return nil
+			}
+
+			err = e.copyFile(fileInfoName, entry.src, dst, entry.relDst, mtime, &option, manifest)
+			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: entry.relDst, Category: entry.category, Cause: err})
+					return nil
+				}
+				return err
+			}
+
+			if resume != nil {
+				return resume.markDone(fileInfoName)
+			}
+			return nil
+		})
+	}
+
+	if err := p.Wait(); err != nil {
+		return err
+	}
+	if option.DryRun {
+		return nil
+	}
+	return manifest.save(e.fs, outputDir)
+}
+
+// planAction runs the exact same decision copyFile would make, including
+// hashing src when the cheap size/mtime check is inconclusive, so a DryRun
+// report can't claim "copy" for a file the real run would actually skip. It
+// only reads src and stats dst; it never touches outputDir.
+func (e *Library) planAction(fileID string, src string, dst string, fileMtime int64, uncategorized bool, overwrite bool, manifest *Manifest) (ExportActionKind, error) {
+	if uncategorized {
+		return ActionUncategorized, nil
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return "", errors.Wrap(err, "open src file failed")
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	decision, err := e.decideCopy(srcFile, fileID, dst, fileMtime, overwrite, manifest)
+	if err != nil {
+		return "", err
+	}
+	return decision.action, nil
+}
+
+// copyDecision is what decideCopy determined should happen to one file,
+// shared between the real copyFile and DryRun's planAction so they can
+// never disagree about what a given file needs.
+type copyDecision struct {
+	action ExportActionKind
+	size   int64
+	hash   string
+}
+
+// decideCopy inspects the manifest and dst's actual state to decide what
+// copyFile should do with src, hashing it when the cheap size/mtime check
+// from the manifest is inconclusive. It only reads srcFile (whose offset is
+// not rewound on return) and stats/reads dst; it never writes anything.
+func (e *Library) decideCopy(srcFile *os.File, fileID string, dst string, fileMtime int64, overwrite bool, manifest *Manifest) (copyDecision, error) {
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return copyDecision{}, errors.Wrap(err, "stat src file failed")
+	}
+	size := srcInfo.Size()
+
+	// A manifest entry only proves dst is up to date if dst is still there;
+	// manual cleanup, a restore from an older backup, or moving files out of
+	// outputDir without touching the manifest must not be read as "already
+	// exported" and silently skipped forever. This Stat runs once per file on
+	// every export, so like MkdirAll/OpenFile/Chtimes below it goes through
+	// withRetry -- except when the error is a genuine "dst doesn't exist",
+	// which retrying can never fix and which is the expected outcome for
+	// every file's first export.
+	var dstExists bool
+	if err := withRetry(func() error {
+		_, statErr := e.fs.Stat(dst)
+		if statErr == nil {
+			dstExists = true
+			return nil
+		}
+		if os.IsNotExist(statErr) {
+			dstExists = false
+			return nil
+		}
+		return statErr
+	}); err != nil {
+		return copyDecision{}, errors.Wrap(err, "stat dst file failed")
+	}
+
+	if !overwrite && dstExists {
+		if entry, ok := manifest.get(fileID); ok && entry.Size == size && entry.SourceMtime == fileMtime {
+			return copyDecision{action: ActionSkip, size: size, hash: entry.Hash}, nil
+		}
+	}
+
+	hash, err := hashFile(srcFile)
+	if err != nil {
+		return copyDecision{}, err
+	}
+
+	if !overwrite && dstExists {
+		if entry, ok := manifest.get(fileID); ok {
+			if entry.Hash == hash {
+				return copyDecision{action: ActionSkip, size: size, hash: hash}, nil
+			}
+		} else {
+			var dstHash string
+			hashErr := withRetry(func() error {
+				h, err := e.hashDst(dst)
+				if err != nil {
+					return err
+				}
+				dstHash = h
+				return nil
+			})
+			if hashErr == nil && dstHash == hash {
+				// No manifest record at all -- e.g. a crash wiped out the
+				// manifest update but dst was already written, or the
+				// manifest sidecar was lost -- but dst's actual content
+				// already matches src, so there's nothing to recopy.
+				return copyDecision{action: ActionSkip, size: size, hash: hash}, nil
+			}
+		}
+	}
+
+	action := ActionCopy
+	if overwrite {
+		action = ActionOverwrite
+	}
+	return copyDecision{action: action, size: size, hash: hash}, nil
+}
+
+// resumeAlreadyExported reports whether fileID can be skipped outright
+// because a prior run's resume log marked it done AND the manifest still has
+// its entry. When the resume log outran the manifest -- e.g. a crash between
+// resume.markDone and manifest.save -- it returns false so the caller falls
+// through to copyFile's own up-to-date check, which repopulates the
+// manifest without needlessly re-copying.
+func resumeAlreadyExported(resume *resumeLog, manifest *Manifest, fileID string) bool {
+	if resume == nil || !resume.isDone(fileID) {
+		return false
+	}
+	_, ok := manifest.get(fileID)
+	return ok
+}
+
+// exportEntry is where one Eagle file id belongs in an export: its source
+// path, the path relative to outputDir it should land at, and (when grouping
+// by smart folder) the category it resolved to.
+type exportEntry struct {
+	fileInfo      FileInfo
+	src           string
+	relDst        string
+	category      string
+	uncategorized bool
+}
+
+// resolveExportEntry loads fileInfoName's metadata and works out where it
+// belongs in the export, shared by Export, exportArchive, and exportHTTP so
+// the three paths can never disagree about naming or categorization. ok is
+// false when the file is deleted in Eagle, which callers should silently skip.
+func (e *Library) resolveExportEntry(fileInfoName string, filter *FolderFilter, groupBySmartFolder bool) (exportEntry, bool, error) {
+	var entry exportEntry
+
+	fileMetadataPath := filepath.Join(e.BaseDir, "images", fileInfoName+".info", "metadata.json")
+	if err := parseJsonFile(fileMetadataPath, &entry.fileInfo); err != nil {
+		return exportEntry{}, false, err
+	}
+
+	if entry.fileInfo.IsDeleted {
+		return exportEntry{}, false, nil
+	}
+
+	infoDir := filepath.Join(e.BaseDir, "images", fileInfoName+".info")
+	fileName := entry.fileInfo.Name + "." + entry.fileInfo.Ext
+	entry.src = filepath.Join(infoDir, fileName)
+
+	if groupBySmartFolder {
+		category, err := filter.Evaluate(&entry.fileInfo)
+		if err != nil {
+			return exportEntry{}, false, err
+		}
+
+		if category == "" {
+			entry.uncategorized = true
+			entry.category = "uncategorized"
+			entry.relDst = filepath.Join("uncategorized", fileName)
+		} else {
+			entry.category = category
+			entry.relDst = filepath.Join(category, fileName)
+		}
+	} else {
+		entry.relDst = fileName
+	}
+
+	return entry, true, nil
+}
+
+// loadExportSources reads mtime.json and metadata.json and builds the smart
+// folder filter shared by the directory, archive, and HTTP export paths.
+func (e *Library) loadExportSources() (Mtime, *FolderFilter, int64, error) {
 	var mtimeMap Mtime
 	err := parseJsonFile(filepath.Join(e.BaseDir, "mtime.json"), &mtimeMap)
 	if err != nil {
-		return err
+		return nil, nil, 0, err
 	}
 
 	var libraryMetadata LibraryInfo
 	err = parseJsonFile(filepath.Join(e.BaseDir, "metadata.json"), &libraryMetadata)
 	if err != nil {
-		return err
+		return nil, nil, 0, err
 	}
 
 	filter := NewFolderFilter(&libraryMetadata)
 
 	count, ok := mtimeMap["all"]
 	if !ok {
-		return errors.New("field 'all' not exists")
+		return nil, nil, 0, errors.New("field 'all' not exists")
+	}
+
+	return mtimeMap, filter, count, nil
+}
+
+// exportArchive mirrors Export but streams every entry into a single
+// tar/tar.gz/zip archive via a serialized writer goroutine, instead of
+// copying files into outputDir, so memory stays bounded on large libraries.
+func (e *Library) exportArchive(outputDir string, option ExportOption) error {
+	mtimeMap, filter, count, err := e.loadExportSources()
+	if err != nil {
+		return err
+	}
+
+	// A single archive is written from scratch every run, so there is no
+	// persisted destination state to compare against: the manifest's
+	// size/mtime/hash skip logic and the resume log's "already finished"
+	// markers both assume a dst that survives between runs, which an archive
+	// stream never has. DryRun and Report still apply -- there's just never
+	// anything to report but ActionCopy/ActionUncategorized.
+	w := option.ArchiveWriter
+	if w == nil && !option.DryRun {
+		f, err := os.Create(outputDir)
+		if err != nil {
+			return errors.Wrapf(err, "create archive file '%v' failed", outputDir)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	var aw *archiveWriter
+	if !option.DryRun {
+		aw, err = newArchiveWriter(option.ArchiveFormat, w)
+		if err != nil {
+			return err
+		}
 	}
 
 	bar := option.Bar
@@ -82,45 +429,96 @@ func (e *Library) Export(outputDir string, option ExportOption) error {
 		}
 
 		p.Go(func() error {
-			var fileInfo FileInfo
-			fileMetadataPath := filepath.Join(e.BaseDir, "images", fileInfoName+".info", "metadata.json")
-			err = parseJsonFile(fileMetadataPath, &fileInfo)
+			entry, ok, err := e.resolveExportEntry(fileInfoName, filter, option.GroupBySmartFolder)
 			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: fileInfoName, Cause: err})
+					return nil
+				}
 				return err
 			}
-
-			if fileInfo.IsDeleted {
+			if !ok {
 				return nil
 			}
 
-			infoDir := filepath.Join(e.BaseDir, "images", fileInfoName+".info")
-			fileName := fileInfo.Name + "." + fileInfo.Ext
-			src := filepath.Join(infoDir, fileName)
+			action := ActionCopy
+			if entry.uncategorized {
+				action = ActionUncategorized
+			}
 
-			var dst string
-			if option.GroupBySmartFolder {
-				var category string
-				category, err = filter.Evaluate(&fileInfo)
-				if err != nil {
-					return err
+			if option.DryRun {
+				if option.Report != nil {
+					option.Report.addPlanned(PlannedAction{Path: entry.relDst, Category: entry.category, Action: action})
 				}
+				return nil
+			}
 
-				if category == "" {
-					dst = filepath.Join(outputDir, "uncategorized", fileName)
-				} else {
-					dst = filepath.Join(outputDir, category, fileName)
+			err = e.copyToArchive(aw, entry.src, entry.relDst, mtime, option.Bar)
+			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: entry.relDst, Category: entry.category, Cause: err})
+					return nil
 				}
-			} else {
-				dst = filepath.Join(outputDir, fileName)
+				return err
 			}
-
-			return e.copyFile(src, dst, mtime, &option)
+			return nil
 		})
 	}
-	return p.Wait()
+
+	if err := p.Wait(); err != nil {
+		if aw != nil {
+			_ = aw.Close()
+		}
+		return err
+	}
+	if aw != nil {
+		return aw.Close()
+	}
+	return nil
+}
+
+func (e *Library) copyToArchive(aw *archiveWriter, src string, name string, fileMtime int64, bar *progressbar.ProgressBar) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "open src file failed")
+	}
+
+	srcStat, err := srcFile.Stat()
+	if err != nil {
+		_ = srcFile.Close()
+		return errors.Wrap(err, "stat src file failed")
+	}
+
+	mtime := time.UnixMilli(fileMtime)
+	var body io.ReadCloser = srcFile
+	if bar != nil {
+		body = &barReadCloser{r: io.TeeReader(srcFile, bar), c: srcFile}
+	}
+
+	return aw.Write(&archiveEntry{
+		name:  name,
+		mtime: mtime,
+		size:  srcStat.Size(),
+		body:  body,
+	})
 }
 
-func (e *Library) copyFile(src string, dst string, fileMtime int64, option *ExportOption) error {
+// barReadCloser reports bytes read through r to a progress bar while still
+// closing the underlying file c.
+type barReadCloser struct {
+	r io.Reader
+	c io.Closer
+}
+
+func (b *barReadCloser) Read(p []byte) (int, error) { return b.r.Read(p) }
+func (b *barReadCloser) Close() error               { return b.c.Close() }
+
+// copyFile copies src to dst unless the manifest shows dst already holds
+// src's content, keyed by fileID (the Eagle file id) rather than by path so
+// renames inside the library don't defeat the incremental check. dst is
+// never opened for writing until that's decided, so a hash match never
+// truncates the existing, still-good destination file.
+func (e *Library) copyFile(fileID string, src string, dst string, relDst string, fileMtime int64, option *ExportOption, manifest *Manifest) error {
 	// TODO: src file is always in the OS fs or not?
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -133,36 +531,59 @@ func (e *Library) copyFile(src string, dst string, fileMtime int64, option *Expo
 		return errors.Wrap(err, "stat src file failed")
 	}
 
-	_ = e.fs.MkdirAll(filepath.Dir(dst), 0755)
-	dstFile, err := e.fs.OpenFile(dst, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0655)
+	decision, err := e.decideCopy(srcFile, fileID, dst, fileMtime, option.Overwrite, manifest)
+	if err != nil {
+		return err
+	}
+	if decision.action == ActionSkip {
+		manifest.set(fileID, manifestEntry{Path: relDst, Size: decision.size, SourceMtime: fileMtime, Hash: decision.hash})
+		return nil
+	}
+
+	if _, err := srcFile.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek src file failed")
+	}
+
+	err = withRetry(func() error { return e.fs.MkdirAll(filepath.Dir(dst), 0755) })
+	if err != nil {
+		return errors.Wrapf(err, "mkdir '%v' failed", filepath.Dir(dst))
+	}
+
+	var dstFile afero.File
+	err = withRetry(func() error {
+		var openErr error
+		dstFile, openErr = e.fs.OpenFile(dst, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0655)
+		return openErr
+	})
 	if err != nil {
 		return errors.Wrap(err, "open dst file failed")
 	}
 	defer func() { _ = dstFile.Close() }()
 
-	dstStat, err := dstFile.Stat()
+	var writer io.Writer
+	if option.Bar != nil {
+		writer = io.MultiWriter(dstFile, option.Bar)
+	} else {
+		writer = dstFile
+	}
+	_, err = io.Copy(writer, srcFile)
 	if err != nil {
-		return errors.Wrap(err, "stat dst file failed")
+		return errors.Wrap(err, "copy file failed")
 	}
 
-	if srcStat.ModTime() != dstStat.ModTime() || fileMtime != dstStat.ModTime().UnixMilli() || option.Overwrite {
-		var writer io.Writer
-		if option.Bar != nil {
-			writer = io.MultiWriter(dstFile, option.Bar)
-		} else {
-			writer = dstFile
-		}
-		_, err = io.Copy(writer, srcFile)
-		if err != nil {
-			return errors.Wrap(err, "copy file failed")
-		}
-		err = e.fs.Chtimes(dst, srcStat.AccessTime(), srcStat.ModTime())
-		if err != nil {
-			return errors.Wrapf(err, "chtimes failed, path: %v", dst)
+	// The manifest (not dst's own mtime) is what incremental sync relies on,
+	// so a backend without mtime support, like WebDAV, still skips unchanged
+	// files correctly. There's nothing to gain from retrying a capability
+	// dst doesn't have, so ErrMtimeUnsupported is checked before paying for
+	// withRetry's backoff.
+	if chtimesErr := e.fs.Chtimes(dst, srcStat.AccessTime(), srcStat.ModTime()); chtimesErr != nil {
+		if !errors.Is(chtimesErr, ErrMtimeUnsupported) {
+			if err := withRetry(func() error { return e.fs.Chtimes(dst, srcStat.AccessTime(), srcStat.ModTime()) }); err != nil {
+				return errors.Wrapf(err, "chtimes failed, path: %v", dst)
+			}
 		}
-	} else {
-		return errors.Wrap(err, "stat dst file failed")
 	}
 
+	manifest.set(fileID, manifestEntry{Path: relDst, Size: decision.size, SourceMtime: fileMtime, Hash: decision.hash})
 	return nil
 }
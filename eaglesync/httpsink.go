@@ -0,0 +1,190 @@
+package eaglesync
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/cockroachdb/errors"
+	"github.com/schollz/progressbar/v3"
+	"github.com/sourcegraph/conc/pool"
+)
+
+// HTTPSinkConfig configures the HTTP multipart exporter: each exported file
+// is POSTed to URL as multipart/form-data, with the file body in the "file"
+// field and its Eagle metadata JSON in the "info" field.
+type HTTPSinkConfig struct {
+	// URL the multipart POST is sent to.
+	URL string
+
+	// Headers added to every upload request (e.g. Authorization).
+	Headers map[string]string
+
+	// Concurrency bounds how many uploads run at once. Defaults to
+	// runtime.NumCPU() when zero.
+	Concurrency int
+
+	// Client is the http.Client used for uploads. Defaults to
+	// http.DefaultClient.
+	Client *http.Client
+}
+
+// exportHTTP mirrors Export but ships every file to option.HTTPSink.URL as a
+// multipart/form-data POST instead of writing it under outputDir.
+func (e *Library) exportHTTP(option ExportOption) error {
+	mtimeMap, filter, count, err := e.loadExportSources()
+	if err != nil {
+		return err
+	}
+
+	cfg := option.HTTPSink
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	// Unlike the directory copy path, there's no manifest here: the
+	// destination is a remote HTTP endpoint whose stored state this library
+	// can't inspect, so there's no size/mtime/hash comparison to skip an
+	// upload on. The resume log still applies -- each id is one independent
+	// POST, exactly like one independent copyFile call -- so a crashed
+	// upload run can still pick up where it left off.
+	var resume *resumeLog
+	if option.ResumeFile != "" {
+		resume, err = openResumeLog(e.fs, option.ResumeFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resume.Close() }()
+	}
+
+	bar := option.Bar
+	if bar != nil {
+		bar.ChangeMax64(count)
+		defer func() { _ = bar.Finish() }()
+	}
+
+	p := pool.New().WithErrors().WithMaxGoroutines(concurrency)
+	for fileInfoName := range mtimeMap {
+		fileInfoName := fileInfoName
+
+		if fileInfoName == "all" {
+			continue
+		}
+
+		p.Go(func() error {
+			if resume != nil && resume.isDone(fileInfoName) {
+				return nil
+			}
+
+			entry, ok, err := e.resolveExportEntry(fileInfoName, filter, option.GroupBySmartFolder)
+			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: fileInfoName, Cause: err})
+					return nil
+				}
+				return err
+			}
+			if !ok {
+				return nil
+			}
+
+			if option.DryRun {
+				action := ActionCopy
+				if entry.uncategorized {
+					action = ActionUncategorized
+				}
+				if option.Report != nil {
+					option.Report.addPlanned(PlannedAction{Path: entry.relDst, Category: entry.category, Action: action})
+				}
+				return nil
+			}
+
+			err = uploadFile(client, cfg, entry.src, entry.relDst, &entry.fileInfo, option.Bar)
+			if err != nil {
+				if option.Report != nil {
+					option.Report.addFailure(ExportFailure{Path: entry.relDst, Category: entry.category, Cause: err})
+					return nil
+				}
+				return err
+			}
+
+			if resume != nil {
+				return resume.markDone(fileInfoName)
+			}
+			return nil
+		})
+	}
+	return p.Wait()
+}
+
+// uploadFile streams src and fileInfo's metadata to cfg.URL as a single
+// multipart/form-data POST via an io.Pipe, so the file is never buffered in
+// memory before it reaches the wire.
+func uploadFile(client *http.Client, cfg *HTTPSinkConfig, src string, relPath string, fileInfo *FileInfo, bar *progressbar.ProgressBar) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "open src file failed")
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	info, err := json.Marshal(fileInfo)
+	if err != nil {
+		return errors.Wrap(err, "marshal file info failed")
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		_ = pw.CloseWithError(func() error {
+			if err := mw.WriteField("info", string(info)); err != nil {
+				return errors.Wrap(err, "write info field failed")
+			}
+
+			part, err := mw.CreateFormFile("file", filepath.Base(relPath))
+			if err != nil {
+				return errors.Wrap(err, "create form file failed")
+			}
+
+			var reader io.Reader = srcFile
+			if bar != nil {
+				reader = io.TeeReader(srcFile, bar)
+			}
+
+			if _, err := io.Copy(part, reader); err != nil {
+				return errors.Wrap(err, "stream file body failed")
+			}
+			return mw.Close()
+		}())
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, cfg.URL, pr)
+	if err != nil {
+		return errors.Wrap(err, "build upload request failed")
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "upload '%v' failed", relPath)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return errors.Newf("upload '%v' failed with status %v", relPath, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package eaglesync
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/afero"
+	"github.com/zeebo/xxh3"
+)
+
+// manifestFileName is the sidecar Export maintains in the destination
+// directory so later runs can tell which files are already up to date.
+const manifestFileName = ".eaglesync-manifest.json"
+
+// manifestEntry records what Export last wrote for one Eagle file id.
+type manifestEntry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	SourceMtime int64  `json:"source_mtime"`
+	Hash        string `json:"hash"`
+}
+
+// Manifest is the content-hash sidecar that makes Export incremental: a
+// file is only re-copied when its content actually changed, not merely
+// because its source mtime moved.
+type Manifest struct {
+	mu      sync.Mutex
+	entries map[string]manifestEntry
+}
+
+func loadManifest(fs afero.Fs, dir string) (*Manifest, error) {
+	m := &Manifest{entries: make(map[string]manifestEntry)}
+
+	f, err := fs.Open(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, errors.Wrap(err, "open manifest failed")
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := json.NewDecoder(f).Decode(&m.entries); err != nil {
+		return nil, errors.Wrap(err, "decode manifest failed")
+	}
+	return m, nil
+}
+
+func (m *Manifest) get(id string) (manifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[id]
+	return entry, ok
+}
+
+func (m *Manifest) set(id string, entry manifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[id] = entry
+}
+
+// save writes the manifest atomically: a temp file next to the destination
+// followed by a rename, so a crash mid-write never corrupts the previous
+// manifest that the next run would otherwise rely on.
+func (m *Manifest) save(fs afero.Fs, dir string) error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "marshal manifest failed")
+	}
+
+	tmp := filepath.Join(dir, manifestFileName+".tmp")
+	f, err := fs.OpenFile(tmp, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrap(err, "create manifest temp file failed")
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "write manifest temp file failed")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "close manifest temp file failed")
+	}
+
+	if err := fs.Rename(tmp, filepath.Join(dir, manifestFileName)); err != nil {
+		return errors.Wrap(err, "rename manifest failed")
+	}
+	return nil
+}
+
+// hashFile returns the hex-encoded xxh3 hash of r's remaining bytes. xxh3 is
+// not cryptographic, but that's the right tradeoff here: the manifest only
+// needs to notice accidental changes across tens of thousands of files fast,
+// not resist deliberate tampering.
+func hashFile(r io.Reader) (string, error) {
+	h := xxh3.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", errors.Wrap(err, "hash file failed")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashDst hashes the file already at dst, for the rare case where copyFile
+// must decide whether to recopy without a manifest entry to trust.
+func (e *Library) hashDst(dst string) (string, error) {
+	f, err := e.fs.Open(dst)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	return hashFile(f)
+}
+
+// Verify re-hashes every file the manifest in dir knows about against what's
+// actually on disk and returns the Eagle file ids whose content no longer
+// matches (bit rot, manual edits, truncated transfers).
+func (e *Library) Verify(dir string) ([]string, error) {
+	manifest, err := loadManifest(e.fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.mu.Lock()
+	entries := make(map[string]manifestEntry, len(manifest.entries))
+	for id, entry := range manifest.entries {
+		entries[id] = entry
+	}
+	manifest.mu.Unlock()
+
+	var mu sync.Mutex
+	var corrupt []string
+
+	p := pool.New().WithErrors().WithMaxGoroutines(runtime.NumCPU())
+	for id, entry := range entries {
+		id := id
+		entry := entry
+
+		p.Go(func() error {
+			f, err := e.fs.Open(filepath.Join(dir, entry.Path))
+			if err != nil {
+				mu.Lock()
+				corrupt = append(corrupt, id)
+				mu.Unlock()
+				return nil
+			}
+			defer func() { _ = f.Close() }()
+
+			hash, err := hashFile(f)
+			if err != nil || hash != entry.Hash {
+				mu.Lock()
+				corrupt = append(corrupt, id)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+
+	return corrupt, nil
+}
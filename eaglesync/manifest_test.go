@@ -0,0 +1,57 @@
+package eaglesync
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestManifestSaveLoadRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	dir := "/export"
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+
+	manifest, err := loadManifest(fs, dir)
+	if err != nil {
+		t.Fatalf("loadManifest on empty dir failed: %v", err)
+	}
+	if _, ok := manifest.get("abc"); ok {
+		t.Fatalf("expected no entry in a freshly loaded manifest")
+	}
+
+	manifest.set("abc", manifestEntry{Path: "uncategorized/a.jpg", Size: 123, SourceMtime: 456, Hash: "deadbeef"})
+	manifest.set("def", manifestEntry{Path: "b.png", Size: 789, SourceMtime: 1011, Hash: "cafef00d"})
+
+	if err := manifest.save(fs, dir); err != nil {
+		t.Fatalf("manifest.save failed: %v", err)
+	}
+
+	reloaded, err := loadManifest(fs, dir)
+	if err != nil {
+		t.Fatalf("loadManifest after save failed: %v", err)
+	}
+
+	entry, ok := reloaded.get("abc")
+	if !ok {
+		t.Fatalf("expected entry 'abc' to survive the round trip")
+	}
+	if entry != (manifestEntry{Path: "uncategorized/a.jpg", Size: 123, SourceMtime: 456, Hash: "deadbeef"}) {
+		t.Fatalf("entry 'abc' came back different: %+v", entry)
+	}
+
+	entry, ok = reloaded.get("def")
+	if !ok {
+		t.Fatalf("expected entry 'def' to survive the round trip")
+	}
+	if entry != (manifestEntry{Path: "b.png", Size: 789, SourceMtime: 1011, Hash: "cafef00d"}) {
+		t.Fatalf("entry 'def' came back different: %+v", entry)
+	}
+
+	// save must be atomic (temp file + rename), so the tmp file must not
+	// linger once the real manifest is in place.
+	if exists, _ := afero.Exists(fs, dir+"/"+manifestFileName+".tmp"); exists {
+		t.Fatalf("expected manifest temp file to be gone after save")
+	}
+}
@@ -0,0 +1,56 @@
+package eaglesync
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/afero"
+)
+
+// ErrMtimeUnsupported is returned by a destination afero.Fs's Chtimes when
+// the backend has no way to store file modification times (e.g. WebDAV).
+// copyFile treats it as non-fatal and skips mtime preservation.
+var ErrMtimeUnsupported = errors.New("destination does not support preserving mtimes")
+
+// NewFsFromURL builds the afero.Fs backing an export destination from a
+// location string: a plain local path, or a URL such as "s3://bucket/prefix",
+// "sftp://user@host/path", or "webdav://host/path". This is what lets the CLI
+// point Export at a remote backend without copyFile knowing the difference.
+func NewFsFromURL(location string) (afero.Fs, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" {
+		return afero.NewOsFs(), nil
+	}
+
+	switch u.Scheme {
+	case "s3":
+		return newS3Fs(u)
+	case "sftp":
+		return newSFTPFs(u)
+	case "webdav":
+		return newWebDAVFs(u)
+	default:
+		return nil, errors.Newf("unsupported destination scheme '%v'", u.Scheme)
+	}
+}
+
+// withRetry retries fn a few times with exponential backoff, for transient
+// failures against remote destination backends (S3/SFTP/WebDAV).
+func withRetry(fn func() error) error {
+	const maxAttempts = 4
+	backoff := 200 * time.Millisecond
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return err
+}
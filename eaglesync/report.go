@@ -0,0 +1,50 @@
+package eaglesync
+
+import "sync"
+
+// ExportActionKind is what DryRun determined it would do for a file.
+type ExportActionKind string
+
+const (
+	ActionCopy          ExportActionKind = "copy"
+	ActionSkip          ExportActionKind = "skip"
+	ActionOverwrite     ExportActionKind = "overwrite"
+	ActionUncategorized ExportActionKind = "uncategorized"
+)
+
+// ExportFailure is one file Export could not process. Cause is wrapped with
+// github.com/cockroachdb/errors, so fmt.Sprintf("%+v", f.Cause) includes a
+// stack trace pointing at where the failure actually happened.
+type ExportFailure struct {
+	Path     string
+	Category string
+	Cause    error
+}
+
+// PlannedAction is one file's outcome as predicted by ExportOption.DryRun.
+type PlannedAction struct {
+	Path     string
+	Category string
+	Action   ExportActionKind
+}
+
+// ExportReport collects per-file outcomes across an Export call instead of
+// aborting on the first failure: Failures holds files that could not be
+// copied, Planned holds the actions a DryRun export would have taken.
+type ExportReport struct {
+	mu       sync.Mutex
+	Failures []ExportFailure
+	Planned  []PlannedAction
+}
+
+func (r *ExportReport) addFailure(failure ExportFailure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Failures = append(r.Failures, failure)
+}
+
+func (r *ExportReport) addPlanned(planned PlannedAction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Planned = append(r.Planned, planned)
+}
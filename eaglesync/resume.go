@@ -0,0 +1,68 @@
+package eaglesync
+
+import (
+	"bufio"
+	"os"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/afero"
+)
+
+// resumeLog is an append-only newline-delimited list of Eagle file ids that
+// have already been exported, so a re-run after a crash can skip everything
+// a prior run already finished instead of starting over.
+type resumeLog struct {
+	mu   sync.Mutex
+	done map[string]bool
+	f    afero.File
+}
+
+func openResumeLog(fs afero.Fs, path string) (*resumeLog, error) {
+	done := make(map[string]bool)
+
+	if existing, err := fs.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		for scanner.Scan() {
+			done[scanner.Text()] = true
+		}
+		_ = existing.Close()
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "open resume file failed")
+	}
+
+	f, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "open resume file for append failed")
+	}
+
+	return &resumeLog{done: done, f: f}, nil
+}
+
+func (r *resumeLog) isDone(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done[id]
+}
+
+// markDone records id as finished and flushes it to disk immediately, so a
+// crash right after only loses work that was genuinely in flight.
+func (r *resumeLog) markDone(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done[id] {
+		return nil
+	}
+	r.done[id] = true
+
+	_, err := r.f.Write([]byte(id + "\n"))
+	if err != nil {
+		return errors.Wrap(err, "write resume file failed")
+	}
+	return nil
+}
+
+func (r *resumeLog) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,83 @@
+package eaglesync
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestResumeAlreadyExported(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := fs.MkdirAll("/export", 0755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+
+	resume, err := openResumeLog(fs, "/export/.eaglesync-resume.log")
+	if err != nil {
+		t.Fatalf("openResumeLog failed: %v", err)
+	}
+	t.Cleanup(func() { _ = resume.Close() })
+
+	manifest, err := loadManifest(fs, "/export")
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+
+	if resumeAlreadyExported(nil, manifest, "abc") {
+		t.Fatalf("nil resume log must never claim a file is already exported")
+	}
+
+	if resumeAlreadyExported(resume, manifest, "abc") {
+		t.Fatalf("a file the resume log has never seen must not be skipped")
+	}
+
+	if err := resume.markDone("abc"); err != nil {
+		t.Fatalf("markDone failed: %v", err)
+	}
+
+	// This is the crash gap: the resume log says "abc" finished, but the
+	// manifest was never updated (e.g. the process died between
+	// resume.markDone and manifest.save). Trusting the resume log alone here
+	// would permanently skip re-deriving the manifest entry for "abc".
+	if resumeAlreadyExported(resume, manifest, "abc") {
+		t.Fatalf("resume log being ahead of the manifest must not short-circuit export")
+	}
+
+	manifest.set("abc", manifestEntry{Path: "abc.jpg", Size: 1, SourceMtime: 2, Hash: "h"})
+
+	if !resumeAlreadyExported(resume, manifest, "abc") {
+		t.Fatalf("once the manifest catches up, the resume log should allow skipping")
+	}
+}
+
+func TestResumeLogPersistsAcrossReopen(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := fs.MkdirAll("/export", 0755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	path := "/export/.eaglesync-resume.log"
+
+	resume, err := openResumeLog(fs, path)
+	if err != nil {
+		t.Fatalf("openResumeLog failed: %v", err)
+	}
+	if err := resume.markDone("abc"); err != nil {
+		t.Fatalf("markDone failed: %v", err)
+	}
+	if err := resume.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := openResumeLog(fs, path)
+	if err != nil {
+		t.Fatalf("reopening resume log failed: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+
+	if !reopened.isDone("abc") {
+		t.Fatalf("expected 'abc' to still be marked done after reopening the log")
+	}
+	if reopened.isDone("def") {
+		t.Fatalf("'def' was never marked done")
+	}
+}
@@ -0,0 +1,38 @@
+package eaglesync
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	s3afero "github.com/fclairamb/afero-s3"
+	"github.com/spf13/afero"
+)
+
+// newS3Fs builds an afero.Fs rooted at a bucket (and optional key prefix)
+// from a "s3://bucket/prefix" URL. Region can be overridden with a
+// "?region=" query parameter; it otherwise falls back to the AWS SDK's
+// usual credential chain and AWS_REGION.
+func newS3Fs(u *url.URL) (afero.Fs, error) {
+	bucket := u.Host
+	prefix := strings.TrimPrefix(u.Path, "/")
+
+	cfg := aws.NewConfig()
+	if region := u.Query().Get("region"); region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// afero-s3 v0.3.1's NewFs takes the *session.Session itself and calls
+	// s3.New on it internally.
+	fs := s3afero.NewFs(bucket, sess)
+	if prefix != "" {
+		return afero.NewBasePathFs(fs, prefix), nil
+	}
+	return fs, nil
+}
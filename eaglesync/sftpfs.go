@@ -0,0 +1,169 @@
+package eaglesync
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newSFTPFs dials an SSH+SFTP destination described by a
+// "sftp://user@host[:port]/path" URL, authenticating via the ssh-agent (the
+// common case for an rclone-style sync tool run interactively or in CI with
+// SSH_AUTH_SOCK set), and returns an afero.Fs rooted at the URL's path.
+func newSFTPFs(u *url.URL) (afero.Fs, error) {
+	user := u.User.Username()
+	if user == "" {
+		user = "root"
+	}
+
+	hostKeyCallback, err := knownhosts.New(os.ExpandEnv("$HOME/.ssh/known_hosts"))
+	if err != nil {
+		return nil, errors.Wrap(err, "load known_hosts failed")
+	}
+
+	auth, err := sshAgentAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr += ":22"
+	}
+
+	conn, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "ssh dial '%v' failed", addr)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "sftp handshake failed")
+	}
+
+	root := u.Path
+	if root == "" {
+		root = "."
+	}
+	return afero.NewBasePathFs(&sftpFs{client: client}, root), nil
+}
+
+// sftpFs adapts a *sftp.Client to afero.Fs.
+type sftpFs struct {
+	client *sftp.Client
+}
+
+func (s *sftpFs) Name() string { return "sftpFs" }
+
+func (s *sftpFs) Create(name string) (afero.File, error) {
+	f, err := s.client.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpFile{f: f, client: s.client, name: name}, nil
+}
+
+func (s *sftpFs) Mkdir(name string, _ os.FileMode) error { return s.client.Mkdir(name) }
+
+func (s *sftpFs) MkdirAll(path string, _ os.FileMode) error { return s.client.MkdirAll(path) }
+
+func (s *sftpFs) Open(name string) (afero.File, error) {
+	f, err := s.client.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpFile{f: f, client: s.client, name: name}, nil
+}
+
+func (s *sftpFs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	f, err := s.client.OpenFile(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpFile{f: f, client: s.client, name: name}, nil
+}
+
+func (s *sftpFs) Remove(name string) error { return s.client.Remove(name) }
+
+func (s *sftpFs) RemoveAll(path string) error { return s.client.RemoveAll(path) }
+
+func (s *sftpFs) Rename(oldname, newname string) error { return s.client.Rename(oldname, newname) }
+
+func (s *sftpFs) Stat(name string) (os.FileInfo, error) { return s.client.Stat(name) }
+
+func (s *sftpFs) Chmod(name string, mode os.FileMode) error { return s.client.Chmod(name, mode) }
+
+func (s *sftpFs) Chown(name string, uid, gid int) error { return s.client.Chown(name, uid, gid) }
+
+func (s *sftpFs) Chtimes(name string, atime, mtime time.Time) error {
+	return s.client.Chtimes(name, atime, mtime)
+}
+
+// sftpFile adapts a *sftp.File to afero.File, filling in directory listing
+// (which pkg/sftp only exposes on the client, not the open file handle).
+type sftpFile struct {
+	f      *sftp.File
+	client *sftp.Client
+	name   string
+}
+
+func (s *sftpFile) Close() error                                 { return s.f.Close() }
+func (s *sftpFile) Read(p []byte) (int, error)                   { return s.f.Read(p) }
+func (s *sftpFile) ReadAt(p []byte, off int64) (int, error)      { return s.f.ReadAt(p, off) }
+func (s *sftpFile) Seek(offset int64, whence int) (int64, error) { return s.f.Seek(offset, whence) }
+func (s *sftpFile) Write(p []byte) (int, error)                  { return s.f.Write(p) }
+func (s *sftpFile) WriteAt(p []byte, off int64) (int, error)     { return s.f.WriteAt(p, off) }
+func (s *sftpFile) WriteString(str string) (int, error)          { return s.f.Write([]byte(str)) }
+func (s *sftpFile) Name() string                                 { return s.name }
+func (s *sftpFile) Stat() (os.FileInfo, error)                   { return s.f.Stat() }
+func (s *sftpFile) Sync() error                                  { return nil }
+func (s *sftpFile) Truncate(size int64) error                    { return s.f.Truncate(size) }
+
+func (s *sftpFile) Readdir(count int) ([]os.FileInfo, error) {
+	entries, err := s.client.ReadDir(s.name)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 && count < len(entries) {
+		entries = entries[:count]
+	}
+	return entries, nil
+}
+
+func (s *sftpFile) Readdirnames(count int) ([]string, error) {
+	entries, err := s.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+func sshAgentAuth() (ssh.AuthMethod, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, errors.New("SSH_AUTH_SOCK not set; sftp destinations require an ssh-agent")
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial ssh-agent failed")
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), nil
+}
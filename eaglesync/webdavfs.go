@@ -0,0 +1,161 @@
+package eaglesync
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/afero"
+	"github.com/studio-b12/gowebdav"
+)
+
+// newWebDAVFs builds an afero.Fs over a "webdav://[user:pass@]host/path"
+// destination. gowebdav has no streaming, random-access writer, so
+// webdavFile buffers writes and flushes the whole file on Close.
+func newWebDAVFs(u *url.URL) (afero.Fs, error) {
+	user := ""
+	pass := ""
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+
+	root := "https://" + u.Host
+	client := gowebdav.NewClient(root, user, pass)
+	if err := client.Connect(); err != nil {
+		return nil, errors.Wrapf(err, "webdav connect to '%v' failed", root)
+	}
+
+	fs := &webdavFs{client: client}
+	if u.Path != "" && u.Path != "/" {
+		return afero.NewBasePathFs(fs, u.Path), nil
+	}
+	return fs, nil
+}
+
+// webdavFs adapts a *gowebdav.Client to afero.Fs. WebDAV has no standard way
+// to set a file's mtime, so Chtimes always reports ErrMtimeUnsupported.
+type webdavFs struct {
+	client *gowebdav.Client
+}
+
+func (w *webdavFs) Name() string { return "webdavFs" }
+
+func (w *webdavFs) Create(name string) (afero.File, error) {
+	return &webdavFile{client: w.client, name: name}, nil
+}
+
+func (w *webdavFs) Mkdir(name string, _ os.FileMode) error { return w.client.Mkdir(name, 0755) }
+
+func (w *webdavFs) MkdirAll(path string, _ os.FileMode) error { return w.client.MkdirAll(path, 0755) }
+
+func (w *webdavFs) Open(name string) (afero.File, error) {
+	data, err := w.client.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	return &webdavFile{client: w.client, name: name, read: bytes.NewReader(data)}, nil
+}
+
+func (w *webdavFs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	if flag&os.O_RDWR == 0 && flag&os.O_WRONLY == 0 {
+		return w.Open(name)
+	}
+	f := &webdavFile{client: w.client, name: name}
+	if flag&os.O_APPEND != 0 {
+		// Close uploads the whole buffer, so an append starts from the
+		// file's existing content.
+		if data, err := w.client.Read(name); err == nil {
+			_, _ = f.write.Write(data)
+		}
+	}
+	return f, nil
+}
+
+func (w *webdavFs) Remove(name string) error { return w.client.Remove(name) }
+
+func (w *webdavFs) RemoveAll(path string) error { return w.client.RemoveAll(path) }
+
+func (w *webdavFs) Rename(oldname, newname string) error {
+	return w.client.Rename(oldname, newname, true)
+}
+
+func (w *webdavFs) Stat(name string) (os.FileInfo, error) { return w.client.Stat(name) }
+
+func (w *webdavFs) Chmod(string, os.FileMode) error { return nil }
+
+func (w *webdavFs) Chown(string, int, int) error { return nil }
+
+func (w *webdavFs) Chtimes(string, time.Time, time.Time) error { return ErrMtimeUnsupported }
+
+// webdavFile buffers writes in memory and uploads the whole file on Close,
+// since gowebdav has no incremental/streaming write. A file opened for
+// reading uploads nothing on Close.
+type webdavFile struct {
+	client *gowebdav.Client
+	name   string
+	read   *bytes.Reader
+	write  bytes.Buffer
+}
+
+func (w *webdavFile) Read(p []byte) (int, error) {
+	if w.read == nil {
+		return 0, errors.New("file not open for reading")
+	}
+	return w.read.Read(p)
+}
+
+func (w *webdavFile) ReadAt(p []byte, off int64) (int, error) {
+	if w.read == nil {
+		return 0, errors.New("file not open for reading")
+	}
+	return w.read.ReadAt(p, off)
+}
+
+func (w *webdavFile) Seek(offset int64, whence int) (int64, error) {
+	if w.read == nil {
+		return 0, errors.New("file not open for reading")
+	}
+	return w.read.Seek(offset, whence)
+}
+
+func (w *webdavFile) Write(p []byte) (int, error)            { return w.write.Write(p) }
+func (w *webdavFile) WriteAt(p []byte, _ int64) (int, error) { return w.write.Write(p) }
+func (w *webdavFile) WriteString(s string) (int, error)      { return w.write.WriteString(s) }
+func (w *webdavFile) Name() string                           { return w.name }
+func (w *webdavFile) Sync() error                            { return nil }
+func (w *webdavFile) Truncate(int64) error                   { return nil }
+
+func (w *webdavFile) Stat() (os.FileInfo, error) { return w.client.Stat(w.name) }
+
+func (w *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	entries, err := w.client.ReadDir(w.name)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 && count < len(entries) {
+		entries = entries[:count]
+	}
+	return entries, nil
+}
+
+func (w *webdavFile) Readdirnames(count int) ([]string, error) {
+	entries, err := w.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+func (w *webdavFile) Close() error {
+	if w.read != nil {
+		return nil
+	}
+	return w.client.Write(w.name, w.write.Bytes(), 0644)
+}